@@ -0,0 +1,210 @@
+package collection
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// ErrTooManyWaiters is returned by BoundedQueue.Acquire when MaxWaiters callers are already
+// parked waiting for admission.
+var ErrTooManyWaiters = errors.New("admission queue: too many waiters")
+
+// BoundedQueue is a byte-budgeted admission gate. It admits a caller immediately if the
+// current in-flight bytes plus the requested size fit within MaxBytes, parks the caller on a
+// FIFO wait list until enough bytes are Released otherwise, and rejects outright with
+// ErrTooManyWaiters once MaxWaiters callers are already parked. It gates the exporter's Export
+// calls so a slow collector sheds load instead of letting buffered observations grow without
+// bound. Because the sample app's PeriodicReader only ever has one export in flight on its own
+// timer, observing the wait-list and ErrTooManyWaiters paths requires a second concurrent
+// export attempt racing it -- e.g. the app's /flush endpoint, called concurrently with the
+// reader's timer, against a slow collector.
+type BoundedQueue struct {
+	MaxBytes   int64
+	MaxWaiters int
+
+	mu       sync.Mutex
+	inFlight int64
+	waiters  *list.List // of *admissionWaiter
+}
+
+type admissionWaiter struct {
+	size  int64
+	ready chan struct{}
+}
+
+// NewBoundedQueue returns a BoundedQueue admitting at most maxBytes in-flight bytes at a
+// time, parking at most maxWaiters callers beyond that.
+func NewBoundedQueue(maxBytes int64, maxWaiters int) *BoundedQueue {
+	return &BoundedQueue{
+		MaxBytes:   maxBytes,
+		MaxWaiters: maxWaiters,
+		waiters:    list.New(),
+	}
+}
+
+// Acquire admits sizeBytes, blocking in FIFO order behind any earlier waiters until enough
+// bytes are Released if the queue can't admit it immediately. It returns ErrTooManyWaiters
+// without blocking if MaxWaiters callers are already parked, and ctx.Err() if ctx is done
+// before admission succeeds.
+func (q *BoundedQueue) Acquire(ctx context.Context, sizeBytes int64) error {
+	q.mu.Lock()
+	if q.waiters.Len() == 0 && q.inFlight+sizeBytes <= q.MaxBytes {
+		q.inFlight += sizeBytes
+		q.mu.Unlock()
+		return nil
+	}
+	if q.waiters.Len() >= q.MaxWaiters {
+		q.mu.Unlock()
+		return ErrTooManyWaiters
+	}
+	w := &admissionWaiter{size: sizeBytes, ready: make(chan struct{})}
+	elem := q.waiters.PushBack(w)
+	q.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+		q.mu.Lock()
+		select {
+		case <-w.ready:
+			// Release won the race after ctx fired; give the granted bytes back instead of
+			// leaking them.
+			q.mu.Unlock()
+			q.Release(sizeBytes)
+		default:
+			q.waiters.Remove(elem)
+			q.mu.Unlock()
+		}
+		return ctx.Err()
+	}
+}
+
+// Release returns sizeBytes of in-flight budget to the queue, admitting waiters in FIFO
+// order for as long as the next one in line now fits.
+func (q *BoundedQueue) Release(sizeBytes int64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.inFlight -= sizeBytes
+	for {
+		front := q.waiters.Front()
+		if front == nil {
+			return
+		}
+		w := front.Value.(*admissionWaiter)
+		if q.inFlight+w.size > q.MaxBytes {
+			return
+		}
+		q.inFlight += w.size
+		q.waiters.Remove(front)
+		close(w.ready)
+	}
+}
+
+// InFlightBytes returns the number of bytes currently admitted.
+func (q *BoundedQueue) InFlightBytes() int64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.inFlight
+}
+
+// Waiters returns the number of callers currently parked waiting for capacity.
+func (q *BoundedQueue) Waiters() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.waiters.Len()
+}
+
+// RegisterSelfObservability registers admission.queue.inflight_bytes and
+// admission.queue.waiters gauges against meter, so operators can watch the queue's
+// backpressure alongside the rest of the sample app's metrics.
+func (q *BoundedQueue) RegisterSelfObservability(meter metric.Meter) error {
+	inFlight, err := meter.Int64ObservableGauge(
+		"admission.queue.inflight_bytes",
+		metric.WithDescription("Bytes currently admitted into the admission queue"),
+		metric.WithUnit("By"),
+	)
+	if err != nil {
+		return err
+	}
+	waiters, err := meter.Int64ObservableGauge(
+		"admission.queue.waiters",
+		metric.WithDescription("Callers currently parked waiting for admission queue capacity"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = meter.RegisterCallback(
+		func(_ context.Context, o metric.Observer) error {
+			o.ObserveInt64(inFlight, q.InFlightBytes())
+			o.ObserveInt64(waiters, int64(q.Waiters()))
+			return nil
+		},
+		inFlight, waiters,
+	)
+	return err
+}
+
+// admissionControlledExporter wraps a push metric exporter so every Export call is gated by
+// a BoundedQueue sized from the caller's config, instead of letting the PeriodicReader
+// buffer an unbounded amount of data while the downstream collector is slow.
+type admissionControlledExporter struct {
+	sdkmetric.Exporter
+	queue *BoundedQueue
+}
+
+// NewAdmissionControlledExporter wraps exporter so its Export calls must acquire sizeBytes
+// (estimated from the ResourceMetrics being sent) from queue before the underlying exporter
+// runs, releasing it once the send completes.
+func NewAdmissionControlledExporter(exporter sdkmetric.Exporter, queue *BoundedQueue) sdkmetric.Exporter {
+	return &admissionControlledExporter{Exporter: exporter, queue: queue}
+}
+
+// Export acquires admission for an estimate of rm's size before delegating to the wrapped
+// exporter, and releases it once the send returns.
+func (e *admissionControlledExporter) Export(ctx context.Context, rm *metricdata.ResourceMetrics) error {
+	size := estimateSize(rm)
+	if err := e.queue.Acquire(ctx, size); err != nil {
+		return err
+	}
+	defer e.queue.Release(size)
+	return e.Exporter.Export(ctx, rm)
+}
+
+// estimateSize approximates the wire size of rm as a fixed cost per data point, good enough
+// to size the admission queue's byte budget without depending on the exporter's encoding.
+func estimateSize(rm *metricdata.ResourceMetrics) int64 {
+	const bytesPerDataPoint = 64
+	var points int64
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			switch data := m.Data.(type) {
+			case metricdata.Gauge[int64]:
+				points += int64(len(data.DataPoints))
+			case metricdata.Gauge[float64]:
+				points += int64(len(data.DataPoints))
+			case metricdata.Sum[int64]:
+				points += int64(len(data.DataPoints))
+			case metricdata.Sum[float64]:
+				points += int64(len(data.DataPoints))
+			case metricdata.Histogram[int64]:
+				points += int64(len(data.DataPoints))
+			case metricdata.Histogram[float64]:
+				points += int64(len(data.DataPoints))
+			case metricdata.ExponentialHistogram[int64]:
+				points += int64(len(data.DataPoints))
+			case metricdata.ExponentialHistogram[float64]:
+				points += int64(len(data.DataPoints))
+			}
+		}
+	}
+	return points * bytesPerDataPoint
+}
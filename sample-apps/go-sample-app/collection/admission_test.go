@@ -0,0 +1,106 @@
+package collection
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBoundedQueueAcquireAdmitsImmediatelyWithinBudget(t *testing.T) {
+	q := NewBoundedQueue(100, 1)
+
+	if err := q.Acquire(context.Background(), 40); err != nil {
+		t.Fatalf("Acquire() = %v, want nil", err)
+	}
+	if got := q.InFlightBytes(); got != 40 {
+		t.Fatalf("InFlightBytes() = %d, want 40", got)
+	}
+	if got := q.Waiters(); got != 0 {
+		t.Fatalf("Waiters() = %d, want 0", got)
+	}
+}
+
+func TestBoundedQueueReleasesInFIFOOrder(t *testing.T) {
+	q := NewBoundedQueue(10, 4)
+	if err := q.Acquire(context.Background(), 10); err != nil {
+		t.Fatalf("Acquire() = %v, want nil", err)
+	}
+
+	// Start waiters one at a time, waiting for each to actually park before starting the
+	// next, so their arrival order (and therefore the FIFO order Release must honor) is
+	// deterministic.
+	const waiterCount = 3
+	admitted := make(chan int, waiterCount)
+	for i := 0; i < waiterCount; i++ {
+		i := i
+		go func() {
+			if err := q.Acquire(context.Background(), 10); err != nil {
+				t.Errorf("Acquire() = %v, want nil", err)
+				return
+			}
+			admitted <- i
+		}()
+		for q.Waiters() != i+1 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	for i := 0; i < waiterCount; i++ {
+		q.Release(10)
+		if got := <-admitted; got != i {
+			t.Fatalf("waiter %d admitted out of FIFO order, got waiter %d", i, got)
+		}
+	}
+}
+
+func TestBoundedQueueRejectsBeyondMaxWaiters(t *testing.T) {
+	q := NewBoundedQueue(10, 1)
+	if err := q.Acquire(context.Background(), 10); err != nil {
+		t.Fatalf("Acquire() = %v, want nil", err)
+	}
+
+	parkedReady := make(chan struct{})
+	go func() {
+		close(parkedReady)
+		_ = q.Acquire(context.Background(), 10)
+	}()
+	<-parkedReady
+	for q.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	if err := q.Acquire(context.Background(), 1); err != ErrTooManyWaiters {
+		t.Fatalf("Acquire() = %v, want ErrTooManyWaiters", err)
+	}
+}
+
+func TestBoundedQueueAcquireContextCancelCleansUpWaiter(t *testing.T) {
+	q := NewBoundedQueue(10, 1)
+	if err := q.Acquire(context.Background(), 10); err != nil {
+		t.Fatalf("Acquire() = %v, want nil", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- q.Acquire(ctx, 10)
+	}()
+	for q.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("Acquire() = %v, want context.Canceled", err)
+	}
+	if got := q.Waiters(); got != 0 {
+		t.Fatalf("Waiters() = %d after cancel, want 0", got)
+	}
+
+	// With the cancelled waiter gone, released bytes go to no stale waiter, so a new
+	// Acquire is admitted immediately.
+	q.Release(10)
+	if err := q.Acquire(context.Background(), 5); err != nil {
+		t.Fatalf("Acquire() after cancel cleanup = %v, want nil", err)
+	}
+}
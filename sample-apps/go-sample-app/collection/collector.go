@@ -6,30 +6,32 @@ import (
 	"math/rand"
 	"time"
 
-	"go.opentelemetry.io/otel/metric/global"
-	"go.opentelemetry.io/otel/metric/instrument"
-	"go.opentelemetry.io/otel/metric/instrument/asyncint64"
-	"go.opentelemetry.io/otel/metric/instrument/syncint64"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
 )
 
 var (
-	meter               = global.MeterProvider().Meter("OTLP_METRIC_SAMPLE_APP")
 	threadsActive int64 = 0
 	threadsBool         = true
 )
 
 // randomMetricCollector contains all the random based metric instruments.
 type randomMetricCollector struct {
-	timeAlive     syncint64.Counter
-	cpuUsage      asyncint64.Gauge
-	heapSize      asyncint64.UpDownCounter
-	threadsActive syncint64.UpDownCounter
+	meter         metric.Meter
+	timeAlive     metric.Int64Counter
+	cpuUsage      metric.Int64ObservableGauge
+	heapSize      metric.Int64ObservableUpDownCounter
+	threadsActive metric.Int64UpDownCounter
 }
 
-// NewRandomMetricCollector returns a new type struct that holds and registers the 4 metric instruments used in the Go-Sample-App;
-// HeapSize, ThreadsActive, TimeAlive, CpuUsage
-func NewRandomMetricCollector() randomMetricCollector {
-	rmc := randomMetricCollector{}
+// NewRandomMetricCollector returns a new type struct that holds and registers the 4 metric
+// instruments used in the Go-Sample-App; HeapSize, ThreadsActive, TimeAlive, CpuUsage.
+// Instruments are created on the Meter obtained from mp rather than a package-level global,
+// so the caller controls which MeterProvider (and therefore exporter) backs them.
+func NewRandomMetricCollector(mp metric.MeterProvider) randomMetricCollector {
+	rmc := randomMetricCollector{meter: mp.Meter("OTLP_METRIC_SAMPLE_APP")}
 	rmc.registerHeapSize()
 	rmc.registerThreadsActive()
 	rmc.registerTimeAlive()
@@ -39,10 +41,10 @@ func NewRandomMetricCollector() randomMetricCollector {
 
 // registerTimeAlive registers a Synchronous Counter called TimeAlive.
 func (rmc *randomMetricCollector) registerTimeAlive() {
-	timeAlive, err := meter.SyncInt64().Counter(
+	timeAlive, err := rmc.meter.Int64Counter(
 		"Time Alive",
-		instrument.WithDescription("Total amount of time that the application has been alive"),
-		instrument.WithUnit("s"),
+		metric.WithDescription("Total amount of time that the application has been alive"),
+		metric.WithUnit("s"),
 	)
 	if err != nil {
 		fmt.Println(err)
@@ -52,38 +54,36 @@ func (rmc *randomMetricCollector) registerTimeAlive() {
 
 // registerCpuUsage registers an Asynchronous Gauge called CpuUsage.
 func (rmc *randomMetricCollector) registerCpuUsage() {
-	cpuUsage, err := meter.AsyncInt64().Gauge(
+	cpuUsage, err := rmc.meter.Int64ObservableGauge(
 		"CPU Usage",
-		instrument.WithDescription("Cpu usage percent"),
-		instrument.WithUnit("%"),
+		metric.WithDescription("Cpu usage percent"),
+		metric.WithUnit("%"),
 	)
 	if err != nil {
 		fmt.Println(err)
 	}
 	rmc.cpuUsage = cpuUsage
-
 }
 
 // registerHeapSize registers an Asynchronous UpDownCounter called HeapSize.
 func (rmc *randomMetricCollector) registerHeapSize() {
-	totalHeapSize, err := meter.AsyncInt64().UpDownCounter(
+	totalHeapSize, err := rmc.meter.Int64ObservableUpDownCounter(
 		"Total Heap Size",
-		instrument.WithDescription("The current total heap size"),
-		instrument.WithUnit("1"),
+		metric.WithDescription("The current total heap size"),
+		metric.WithUnit("1"),
 	)
 	if err != nil {
 		fmt.Println(err)
 	}
 	rmc.heapSize = totalHeapSize
-
 }
 
 // registerThreadsActive registers a Synchronous UpDownCounter called ThreadsActive.
 func (rmc *randomMetricCollector) registerThreadsActive() {
-	threadsActive, err := meter.SyncInt64().UpDownCounter(
+	threadsActive, err := rmc.meter.Int64UpDownCounter(
 		"Threads Active",
-		instrument.WithUnit("1"),
-		instrument.WithDescription("The total amount of threads active"),
+		metric.WithUnit("1"),
+		metric.WithDescription("The total amount of threads active"),
 	)
 	if err != nil {
 		fmt.Println(err)
@@ -92,13 +92,13 @@ func (rmc *randomMetricCollector) registerThreadsActive() {
 }
 
 // UpdateMetricsClient generates new metric values for Synchronous instruments every TimeInterval and
-// Asynchronous instruments every CollectPeriod configured by the controller.
-func (rmc *randomMetricCollector) UpdateMetricsClient(ctx context.Context, cfg Config) {
+// Asynchronous instruments every time the MeterProvider's reader collects (on the
+// PeriodicReader's timer for OTLP, on each scrape for Prometheus). Each synchronous tick runs
+// inside a span so traces, metrics, and logs can be correlated.
+func (rmc *randomMetricCollector) UpdateMetricsClient(ctx context.Context, cfg Config, tracer trace.Tracer, logger *zap.Logger) {
 	go func() {
 		for {
-			rmc.updateTimeAlive(ctx, cfg)
-			rmc.updateThreadsActive(ctx, cfg)
-			fmt.Println("Updating time alive && threads active...")
+			rmc.tick(ctx, cfg, tracer, logger)
 			time.Sleep(time.Second * time.Duration(cfg.TimeInterval))
 		}
 	}()
@@ -106,6 +106,26 @@ func (rmc *randomMetricCollector) UpdateMetricsClient(ctx context.Context, cfg C
 	rmc.updateTotalHeapSize(ctx, cfg)
 }
 
+// tick runs one synchronous-instrument update cycle inside a span named
+// "randomMetricCollector.tick", recording the generated values as span attributes and
+// emitting a correlated log line carrying the span's trace_id/span_id.
+func (rmc *randomMetricCollector) tick(ctx context.Context, cfg Config, tracer trace.Tracer, logger *zap.Logger) {
+	ctx, span := tracer.Start(ctx, "randomMetricCollector.tick")
+	defer span.End()
+
+	rmc.updateTimeAlive(ctx, cfg)
+	rmc.updateThreadsActive(ctx, cfg)
+
+	span.SetAttributes(
+		attribute.Int64("time_alive.added", cfg.TimeAliveIncrementer),
+		attribute.Int64("threads_active.value", threadsActive),
+	)
+	logWithSpan(logger, span, "Updating time alive && threads active...",
+		zap.Int64("time_alive.added", cfg.TimeAliveIncrementer),
+		zap.Int64("threads_active.value", threadsActive),
+	)
+}
+
 // updateTimeAlive updates TimeAlive by TimeAliveIncrementer increments.
 func (rmc *randomMetricCollector) updateTimeAlive(ctx context.Context, cfg Config) {
 	rmc.timeAlive.Add(ctx, cfg.TimeAliveIncrementer)
@@ -113,19 +133,17 @@ func (rmc *randomMetricCollector) updateTimeAlive(ctx context.Context, cfg Confi
 
 // updateCpuUsage updates CpuUsage by a value between 0 and CpuUsageUpperBound every SDK call.
 func (rmc *randomMetricCollector) updateCpuUsage(ctx context.Context, cfg Config) {
-
-	if err := meter.RegisterCallback(
-		[]instrument.Asynchronous{
-			rmc.cpuUsage,
-		},
+	if _, err := rmc.meter.RegisterCallback(
 		// SDK periodically calls this function to collect data.
-		func(ctx context.Context) {
+		func(_ context.Context, o metric.Observer) error {
 			min := 0
 			max := int(cfg.CpuUsageUpperBound)
 			cpuUsage := int64(rand.Intn(max-min) + min)
-			rmc.cpuUsage.Observe(ctx, cpuUsage)
+			o.ObserveInt64(rmc.cpuUsage, cpuUsage)
 			fmt.Println("CPU Usage asked by SDK")
+			return nil
 		},
+		rmc.cpuUsage,
 	); err != nil {
 		panic(err)
 	}
@@ -133,29 +151,32 @@ func (rmc *randomMetricCollector) updateCpuUsage(ctx context.Context, cfg Config
 
 // updateTotalHeapSize updates HeapSize by a value between 0 and TotalHeapSizeUpperBound every SDK call.
 func (rmc *randomMetricCollector) updateTotalHeapSize(ctx context.Context, cfg Config) {
-	if err := meter.RegisterCallback(
-		[]instrument.Asynchronous{
-			rmc.heapSize,
-		},
+	if _, err := rmc.meter.RegisterCallback(
 		// SDK periodically calls this function to collect data.
-		func(ctx context.Context) {
+		func(_ context.Context, o metric.Observer) error {
 			min := 0
 			max := int(cfg.TotalheapSizeUpperBound)
 			totalHeapSize := int64(rand.Intn(max-min) + min)
-			rmc.heapSize.Observe(ctx, totalHeapSize)
+			o.ObserveInt64(rmc.heapSize, totalHeapSize)
 			fmt.Println("Heapsize asked by SDK")
+			return nil
 		},
+		rmc.heapSize,
 	); err != nil {
 		panic(err)
 	}
 }
 
-// updateThreadsActive updates ThreadsActive by a value between 0 and 10 in increments or decrements of 1 based on previous value.
+// updateThreadsActive updates ThreadsActive by a value between 0 and 10 in increments or
+// decrements of 1 based on previous value. Each Add carries a "thread.source" attribute
+// identifying whether the change came from the increment or decrement side of the cycle, so
+// the "Threads Active" view (which drops that attribute) has two distinct attribute sets to
+// re-aggregate into one series.
 func (rmc *randomMetricCollector) updateThreadsActive(ctx context.Context, cfg Config) {
 
 	if threadsBool {
 		if threadsActive < int64(cfg.ThreadsActiveUpperBound) {
-			rmc.threadsActive.Add(ctx, 1)
+			rmc.threadsActive.Add(ctx, 1, metric.WithAttributes(attribute.String("thread.source", "increment")))
 			threadsActive++
 		} else {
 			threadsBool = false
@@ -164,7 +185,7 @@ func (rmc *randomMetricCollector) updateThreadsActive(ctx context.Context, cfg C
 
 	} else {
 		if threadsActive > 0 {
-			rmc.threadsActive.Add(ctx, -1)
+			rmc.threadsActive.Add(ctx, -1, metric.WithAttributes(attribute.String("thread.source", "decrement")))
 			threadsActive--
 		} else {
 			threadsBool = true
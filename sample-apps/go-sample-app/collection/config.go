@@ -0,0 +1,104 @@
+package collection
+
+import (
+	"io/ioutil"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Default values used when config.yaml is missing or cannot be parsed.
+var (
+	defaultHost                    = "0.0.0.0"
+	defaultPort                    = "4567"
+	defaultTimeInterval            = 1
+	defaultTimeAliveIncrementer    = 1
+	defaultTotalHeapSizeUpperBound = 100
+	defaultThreadsActiveUpperBound = 10
+	defaultCpuUsageUpperBound      = 100
+	defaultExporterType            = "otlphttp"
+	defaultCardinalityLimit        = 0
+	defaultTracesEndpoint          = "0.0.0.0:4318"
+	defaultLogsEndpoint            = "0.0.0.0:4318"
+	defaultTracesSamplerRatio      = 1.0
+	defaultAdmissionMaxBytes       = 1 << 20
+	defaultAdmissionMaxWaiters     = 64
+	defaultTemporality             = "cumulative"
+)
+
+// Config holds the settings read from the sample app's config.yaml, falling back to
+// defaults when the file is missing or cannot be parsed. Fields left unset in a file that
+// parses keep their zero value; they are not filled from the defaults.
+type Config struct {
+	Host                    string `yaml:"Host"`
+	Port                    string `yaml:"Port"`
+	TimeInterval            int64  `yaml:"TimeInterval"`
+	TimeAliveIncrementer    int64  `yaml:"RandomTimeAliveIncrementer"`
+	TotalheapSizeUpperBound int64  `yaml:"RandomTotalHeapSizeUpperBound"`
+	ThreadsActiveUpperBound int64  `yaml:"RandomThreadsActiveUpperBound"`
+	CpuUsageUpperBound      int64  `yaml:"RandomCpuUsageUpperBound"`
+	// ExporterType selects which metrics exporter the sample app starts: "otlphttp" (the
+	// default), "otlpgrpc", or "prometheus". The EXPORTER environment variable overrides
+	// whatever is set here.
+	ExporterType string `yaml:"ExporterType"`
+	// CardinalityLimit caps the number of distinct attribute sets the SDK aggregates per
+	// instrument; once exceeded, additional measurements collapse into a single
+	// otel.metric.overflow=true series instead of growing unbounded. 0 (the default) leaves
+	// the SDK's built-in limit in place.
+	CardinalityLimit int64 `yaml:"CardinalityLimit"`
+	// EnableRandomMetrics turns on randomMetricCollector's synthetic TimeAlive/CpuUsage/
+	// TotalHeapSize/ThreadsActive metrics. It defaults to false; the host metric collector's
+	// real process telemetry is reported regardless.
+	EnableRandomMetrics bool `yaml:"EnableRandomMetrics"`
+	// TracesEndpoint and LogsEndpoint are the OTLP collector addresses the tracing and
+	// logging pipelines export to. TracesSamplerRatio is the fraction (0.0-1.0) of root
+	// spans that get sampled.
+	TracesEndpoint     string  `yaml:"TracesEndpoint"`
+	LogsEndpoint       string  `yaml:"LogsEndpoint"`
+	TracesSamplerRatio float64 `yaml:"TracesSamplerRatio"`
+	// AdmissionMaxBytes and AdmissionMaxWaiters size the BoundedQueue that gates the push
+	// metric exporter's send path: at most AdmissionMaxBytes may be in flight to the
+	// collector at once, and at most AdmissionMaxWaiters callers may be parked waiting for
+	// room before Acquire starts rejecting with ErrTooManyWaiters.
+	AdmissionMaxBytes   int64 `yaml:"AdmissionMaxBytes"`
+	AdmissionMaxWaiters int   `yaml:"AdmissionMaxWaiters"`
+	// Temporality selects the preferred aggregation temporality for the push metric
+	// exporters: "cumulative" (the default), "delta", or "lowmemory".
+	Temporality string `yaml:"Temporality"`
+}
+
+// GetConf reads config.yaml from the working directory, falling back to getDefaultConfig
+// if the file is missing or cannot be parsed.
+func GetConf() *Config {
+	c := &Config{}
+	yamlFile, err := ioutil.ReadFile("config.yaml")
+	if err != nil {
+		//logs here
+		return c.getDefaultConfig()
+	}
+	err = yaml.Unmarshal(yamlFile, c)
+	if err != nil {
+		//more logs here
+		return c.getDefaultConfig()
+	}
+	return c
+}
+
+// getDefaultConfig populates c with the sample app's built-in defaults.
+func (c *Config) getDefaultConfig() *Config {
+	c.Host = defaultHost
+	c.Port = defaultPort
+	c.TimeInterval = int64(defaultTimeInterval)
+	c.TimeAliveIncrementer = int64(defaultTimeAliveIncrementer)
+	c.TotalheapSizeUpperBound = int64(defaultTotalHeapSizeUpperBound)
+	c.ThreadsActiveUpperBound = int64(defaultThreadsActiveUpperBound)
+	c.CpuUsageUpperBound = int64(defaultCpuUsageUpperBound)
+	c.ExporterType = defaultExporterType
+	c.CardinalityLimit = int64(defaultCardinalityLimit)
+	c.TracesEndpoint = defaultTracesEndpoint
+	c.LogsEndpoint = defaultLogsEndpoint
+	c.TracesSamplerRatio = defaultTracesSamplerRatio
+	c.AdmissionMaxBytes = int64(defaultAdmissionMaxBytes)
+	c.AdmissionMaxWaiters = defaultAdmissionMaxWaiters
+	c.Temporality = defaultTemporality
+	return c
+}
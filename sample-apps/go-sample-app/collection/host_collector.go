@@ -0,0 +1,129 @@
+package collection
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/process"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// hostMetricCollector reports genuine process and Go runtime telemetry -- modeled on the
+// service telemetry the OTel Collector itself exports -- so the sample can serve as a
+// realistic reference for collector/backend dashboards, instead of the random values
+// produced by randomMetricCollector.
+type hostMetricCollector struct {
+	meter metric.Meter
+	proc  *process.Process
+	start time.Time
+
+	cpuSeconds     metric.Float64ObservableCounter
+	rss            metric.Int64ObservableGauge
+	heapAlloc      metric.Int64ObservableGauge
+	totalSysMemory metric.Int64ObservableGauge
+	totalAlloc     metric.Int64ObservableCounter
+	uptime         metric.Float64ObservableCounter
+}
+
+// NewHostMetricCollector returns a new collector that registers process.cpu.seconds,
+// process.memory.rss, process.runtime.heap_alloc_bytes, process.runtime.total_sys_memory_bytes,
+// process.runtime.total_alloc_bytes, and process.uptime against the Meter obtained from mp.
+func NewHostMetricCollector(mp metric.MeterProvider) (*hostMetricCollector, error) {
+	proc, err := process.NewProcess(int32(os.Getpid()))
+	if err != nil {
+		return nil, err
+	}
+
+	hmc := &hostMetricCollector{
+		meter: mp.Meter("OTLP_METRIC_SAMPLE_APP"),
+		proc:  proc,
+		start: time.Now(),
+	}
+	if err := hmc.registerInstruments(); err != nil {
+		return nil, err
+	}
+	return hmc, nil
+}
+
+// registerInstruments creates the host metric instruments and registers a single callback
+// that samples all of them together every collection cycle.
+func (hmc *hostMetricCollector) registerInstruments() error {
+	var err error
+	if hmc.cpuSeconds, err = hmc.meter.Float64ObservableCounter(
+		"process.cpu.seconds",
+		metric.WithDescription("Total CPU user and system time spent by the process"),
+		metric.WithUnit("s"),
+	); err != nil {
+		return err
+	}
+	if hmc.rss, err = hmc.meter.Int64ObservableGauge(
+		"process.memory.rss",
+		metric.WithDescription("Resident set size of the process"),
+		metric.WithUnit("By"),
+	); err != nil {
+		return err
+	}
+	if hmc.heapAlloc, err = hmc.meter.Int64ObservableGauge(
+		"process.runtime.heap_alloc_bytes",
+		metric.WithDescription("Bytes of allocated heap objects"),
+		metric.WithUnit("By"),
+	); err != nil {
+		return err
+	}
+	if hmc.totalSysMemory, err = hmc.meter.Int64ObservableGauge(
+		"process.runtime.total_sys_memory_bytes",
+		metric.WithDescription("Total bytes of memory obtained from the OS"),
+		metric.WithUnit("By"),
+	); err != nil {
+		return err
+	}
+	if hmc.totalAlloc, err = hmc.meter.Int64ObservableCounter(
+		"process.runtime.total_alloc_bytes",
+		metric.WithDescription("Cumulative bytes allocated for heap objects"),
+		metric.WithUnit("By"),
+	); err != nil {
+		return err
+	}
+	if hmc.uptime, err = hmc.meter.Float64ObservableCounter(
+		"process.uptime",
+		metric.WithDescription("Time since the application started"),
+		metric.WithUnit("s"),
+	); err != nil {
+		return err
+	}
+
+	_, err = hmc.meter.RegisterCallback(
+		hmc.observe,
+		hmc.cpuSeconds, hmc.rss, hmc.heapAlloc, hmc.totalSysMemory, hmc.totalAlloc, hmc.uptime,
+	)
+	return err
+}
+
+// observe samples process and Go runtime telemetry and reports it against every registered
+// instrument. The SDK invokes it once per collection cycle.
+func (hmc *hostMetricCollector) observe(_ context.Context, o metric.Observer) error {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	if cpuTimes, err := hmc.proc.Times(); err != nil {
+		fmt.Println(err)
+	} else {
+		o.ObserveFloat64(hmc.cpuSeconds, cpuTimes.User+cpuTimes.System)
+	}
+
+	if memInfo, err := hmc.proc.MemoryInfo(); err != nil {
+		fmt.Println(err)
+	} else {
+		o.ObserveInt64(hmc.rss, int64(memInfo.RSS))
+	}
+
+	o.ObserveInt64(hmc.heapAlloc, int64(mem.HeapAlloc))
+	o.ObserveInt64(hmc.totalSysMemory, int64(mem.Sys))
+	o.ObserveInt64(hmc.totalAlloc, int64(mem.TotalAlloc))
+	o.ObserveFloat64(hmc.uptime, time.Since(hmc.start).Seconds())
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package collection
+
+import (
+	"context"
+
+	"go.opentelemetry.io/contrib/bridges/otelzap"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// NewTracerProvider builds a TracerProvider that exports spans over OTLP/HTTP or OTLP/gRPC
+// (matching cfg.ExporterType) to cfg.TracesEndpoint, sampling cfg.TracesSamplerRatio of the
+// root spans it sees.
+func NewTracerProvider(ctx context.Context, cfg Config) (*sdktrace.TracerProvider, error) {
+	var exporter sdktrace.SpanExporter
+	var err error
+	if cfg.ExporterType == "otlpgrpc" {
+		exporter, err = otlptracegrpc.New(ctx,
+			otlptracegrpc.WithInsecure(),
+			otlptracegrpc.WithEndpoint(cfg.TracesEndpoint),
+		)
+	} else {
+		exporter, err = otlptracehttp.New(ctx,
+			otlptracehttp.WithInsecure(),
+			otlptracehttp.WithEndpoint(cfg.TracesEndpoint),
+		)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.TracesSamplerRatio))),
+	)
+	otel.SetTracerProvider(tp)
+	return tp, nil
+}
+
+// NewLogger builds a zap.Logger bridged to OpenTelemetry so its entries are exported over
+// OTLP/HTTP to cfg.LogsEndpoint, and returns the function used to flush and shut the bridge
+// down alongside the logger.
+func NewLogger(ctx context.Context, cfg Config) (*zap.Logger, func(context.Context) error, error) {
+	exporter, err := otlploghttp.New(ctx,
+		otlploghttp.WithInsecure(),
+		otlploghttp.WithEndpoint(cfg.LogsEndpoint),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	provider := sdklog.NewLoggerProvider(sdklog.WithProcessor(sdklog.NewBatchProcessor(exporter)))
+	logger := zap.New(otelzap.NewCore("OTLP_METRIC_SAMPLE_APP", otelzap.WithLoggerProvider(provider)))
+
+	return logger, provider.Shutdown, nil
+}
+
+// logWithSpan writes msg through logger annotated with the trace_id/span_id of span, so log
+// lines can be correlated back to the trace and metrics recorded for the same tick.
+func logWithSpan(logger *zap.Logger, span trace.Span, msg string, fields ...zap.Field) {
+	sc := span.SpanContext()
+	logger.Info(msg, append(fields,
+		zap.String("trace_id", sc.TraceID().String()),
+		zap.String("span_id", sc.SpanID().String()),
+	)...)
+}
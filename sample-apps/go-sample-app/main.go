@@ -3,239 +3,307 @@ package main
 import (
 	"context"
 	"fmt"
-	"io/ioutil"
-	"log"
 	"math/rand"
+	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
 	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
-	"go.opentelemetry.io/otel/metric/global"
-	"go.opentelemetry.io/otel/metric/instrument"
-	controller "go.opentelemetry.io/otel/sdk/metric/controller/basic"
-	"go.opentelemetry.io/otel/sdk/metric/export/aggregation"
-	processor "go.opentelemetry.io/otel/sdk/metric/processor/basic"
-	selector "go.opentelemetry.io/otel/sdk/metric/selector/simple"
-	"gopkg.in/yaml.v3"
-)
+	"go.opentelemetry.io/otel/exporters/prometheus"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.uber.org/zap"
 
-var (
-	meter = global.MeterProvider().Meter("OTLP_METRIC_SAMPLE_APP")
-
-	// Request based metrics; values generated upon endpoint requests
-	totalRequests        string
-	totalPageFaults      string
-	latencyTime          string
-	totalAllocatedMemory string
-	totalActiveReqests   string
-
-	// Default values for random based metrics
-	defaultHost                    = "0.0.0.0"
-	defaultPort                    = "4567"
-	defaultTimeAliveIncrementer    = 1
-	defaultTotalHeapSizeUpperBound = 100
-	defaultThreadsActiveUpperBound = 10
-	defaultCpuUsageUpperBound      = 100
-
-	threadsActive int64 = 0
-	threadsBool         = true
+	"github.com/aws-observability/aws-otel-community/sample-apps/go-sample-app/collection"
 )
 
-// Random based metrics; values inputed by configuration file
-type conf struct {
-	Host                    string `yaml:"Host"`
-	Port                    string `yaml:"Port"`
-	TimeAliveIncrementer    int64  `yaml:"RandomTimeAliveIncrementer"`
-	TotalheapSizeUpperBound int64  `yaml:"RandomTotalHeapSizeUpperBound"`
-	ThreadsActiveUpperBound int64  `yaml:"RandomThreadsActiveUpperBound"`
-	CpuUsageUpperBound      int64  `yaml:"RandomCpuUsageUpperBound"`
-}
-
 func main() {
-	var c conf
-	c.getConf()
+	cfg := collection.GetConf()
+	if exporterType := os.Getenv("EXPORTER"); exporterType != "" {
+		cfg.ExporterType = exporterType
+	}
+
 	ctx := context.Background()
-	shutdown := startClient(ctx)
+	mp, registerHandlers, shutdown, err := startClient(ctx, cfg)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
 	defer shutdown()
 
-	go updateLoop(ctx)
+	if _, err := collection.NewHostMetricCollector(mp); err != nil {
+		fmt.Println(err)
+	}
+
+	tp, err := collection.NewTracerProvider(ctx, *cfg)
+	if err != nil {
+		fmt.Println(err)
+	} else {
+		defer func() {
+			cxt, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			if err := tp.Shutdown(cxt); err != nil {
+				otel.Handle(err)
+			}
+		}()
+	}
+
+	logger, shutdownLogger, err := collection.NewLogger(ctx, *cfg)
+	if err != nil {
+		fmt.Println(err)
+	} else {
+		defer func() {
+			cxt, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			if err := shutdownLogger(cxt); err != nil {
+				otel.Handle(err)
+			}
+		}()
+	}
+
+	if cfg.EnableRandomMetrics {
+		// Random-metric emission shouldn't depend on tracing/logging having started
+		// successfully: fall back to a no-op tracer/logger so a broken trace or log exporter
+		// doesn't also silence metrics the operator asked for.
+		tracer := otel.Tracer("OTLP_METRIC_SAMPLE_APP")
+		if tp != nil {
+			tracer = tp.Tracer("OTLP_METRIC_SAMPLE_APP")
+		}
+		if logger == nil {
+			logger = zap.NewNop()
+		}
+		rmc := collection.NewRandomMetricCollector(mp)
+		rmc.UpdateMetricsClient(ctx, *cfg, tracer, logger)
+	}
+
+	latencyHistogram, err := mp.Meter("OTLP_METRIC_SAMPLE_APP").Int64Histogram(
+		"request.latency",
+		metric.WithDescription("Synthetic request latency recorded by the /latency handler"),
+		metric.WithUnit("ms"),
+	)
+	if err != nil {
+		fmt.Println(err)
+	}
+
+	mux := http.NewServeMux()
+	registerHandlers(mux)
+	mux.HandleFunc("/latency", recordLatencyHandler(latencyHistogram))
+	server := &http.Server{Addr: cfg.Host + ":" + cfg.Port, Handler: mux}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			otel.Handle(err)
+		}
+	}()
+	defer func() {
+		cxt, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		if err := server.Shutdown(cxt); err != nil {
+			otel.Handle(err)
+		}
+	}()
+
 	fmt.Println("Reporting measurements to locahost:3418...")
 	ch := make(chan os.Signal, 3)
 	signal.Notify(ch, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGTERM)
 	<-ch
-
 }
 
-// Function that creates and returns a New client with certain options
-// In this case we are sending insecure options (http instead of https)
-func otlpmetricClient(endpoint string) otlpmetric.Client {
-	options := []otlpmetrichttp.Option{
-		otlpmetrichttp.WithInsecure(),
-		otlpmetrichttp.WithEndpoint(endpoint),
+// recordLatencyHandler returns an http.HandlerFunc that records a synthetic request latency
+// into histogram on every call, so users can validate delta vs cumulative temporality and
+// exponential-histogram encoding end-to-end through an OTel Collector.
+func recordLatencyHandler(histogram metric.Int64Histogram) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		latency := int64(rand.Intn(500))
+		histogram.Record(r.Context(), latency)
+		fmt.Fprintf(w, "recorded request.latency=%dms\n", latency)
 	}
-
-	return otlpmetrichttp.NewClient(options...)
 }
 
-func startClient(ctx context.Context) func() {
-	endpoint := os.Getenv("OTLP_EXPORTER_OTLP_ENDPOINT")
-	if endpoint == "" {
-		endpoint = "0.0.0.0:4318"
+// startClient picks the exporter pipeline configured by cfg.ExporterType (otlphttp,
+// otlpgrpc, or prometheus), builds a MeterProvider around it, and registers that provider as
+// the global one. It returns the MeterProvider so callers can create instruments against it,
+// a function to register any exporter-specific HTTP handlers (e.g. Prometheus's /metrics) on
+// the sample app's shared mux, and a function that shuts the pipeline down. It returns an
+// error, rather than continuing with a broken exporter, if the underlying exporter fails to
+// construct.
+func startClient(ctx context.Context, cfg *collection.Config) (metric.MeterProvider, func(*http.ServeMux), func(), error) {
+	if cfg.CardinalityLimit > 0 {
+		// The SDK's attribute cardinality limit is controlled by an experimental env var;
+		// setting it here lets the sample demonstrate otel.metric.overflow=true behavior.
+		os.Setenv("OTEL_GO_X_CARDINALITY_LIMIT", strconv.FormatInt(cfg.CardinalityLimit, 10))
 	}
-	cumulativeSelector := aggregation.CumulativeTemporalitySelector()
-	metricExp, err := otlpmetric.New(ctx, otlpmetricClient(endpoint), otlpmetric.WithMetricAggregationTemporalitySelector(cumulativeSelector))
-	if err != nil {
-		//Logs here
-	}
-	ctrl := controller.New(
-		processor.NewFactory(
-			selector.NewWithHistogramDistribution(),
-			metricExp,
-		),
-		controller.WithExporter(metricExp),
-		controller.WithCollectPeriod(3*time.Second),
+
+	// Demonstrates view-based re-aggregation: Threads Active is reported without its
+	// "thread.source" attribute, regardless of what the instrument is called with.
+	dropThreadSource := sdkmetric.NewView(
+		sdkmetric.Instrument{Name: "Threads Active"},
+		sdkmetric.Stream{
+			AttributeFilter: func(kv attribute.KeyValue) bool {
+				return kv.Key != "thread.source"
+			},
+		},
+	)
+	// request.latency uses an exponential (base-2) histogram instead of the SDK's default
+	// fixed-bucket histogram, so users can validate exponential-histogram encoding through a
+	// Collector.
+	latencyView := sdkmetric.NewView(
+		sdkmetric.Instrument{Name: "request.latency"},
+		sdkmetric.Stream{
+			Aggregation: sdkmetric.AggregationBase2ExponentialHistogram{MaxSize: 160, MaxScale: 20},
+		},
 	)
-	if err := ctrl.Start(ctx); err != nil {
-		// Logs here
+
+	switch cfg.ExporterType {
+	case "prometheus":
+		return startPrometheusClient(cfg, dropThreadSource, latencyView)
+	case "otlpgrpc":
+		return startOtlpGrpcClient(ctx, cfg, dropThreadSource, latencyView)
+	default:
+		return startOtlpHttpClient(ctx, cfg, dropThreadSource, latencyView)
 	}
-	global.SetMeterProvider(ctrl)
-	// Pass function to shutdown the controller in a defer statement
-	return func() {
-		cxt, cancel := context.WithTimeout(ctx, time.Second)
-		defer cancel()
+}
 
-		// pushes any last exports to the receiver
-		if err := ctrl.Stop(cxt); err != nil {
-			otel.Handle(err)
+// temporalitySelector translates cfg.Temporality ("cumulative", "delta", or "lowmemory")
+// into the matching SDK TemporalitySelector: cumulative always prefers cumulative, delta
+// prefers delta except for UpDownCounters (which can't be meaningfully delta-aggregated),
+// and lowmemory restricts delta to just Counters and Histograms to bound exporter memory.
+func temporalitySelector(cfg *collection.Config) sdkmetric.TemporalitySelector {
+	switch cfg.Temporality {
+	case "delta":
+		return func(kind sdkmetric.InstrumentKind) metricdata.Temporality {
+			switch kind {
+			case sdkmetric.InstrumentKindUpDownCounter, sdkmetric.InstrumentKindObservableUpDownCounter:
+				return metricdata.CumulativeTemporality
+			default:
+				return metricdata.DeltaTemporality
+			}
+		}
+	case "lowmemory":
+		return func(kind sdkmetric.InstrumentKind) metricdata.Temporality {
+			switch kind {
+			case sdkmetric.InstrumentKindCounter, sdkmetric.InstrumentKindHistogram:
+				return metricdata.DeltaTemporality
+			default:
+				return metricdata.CumulativeTemporality
+			}
 		}
+	default:
+		return sdkmetric.DefaultTemporalitySelector
 	}
 }
 
-// Reads the config file and writes to the struct with the appropriate values
-func (c *conf) getConf() *conf {
-	yamlFile, err := ioutil.ReadFile("config.yaml")
-	if err != nil {
-		//logs here
-		return c.getDefaultConfig()
+// startOtlpHttpClient pushes metrics to a collector over OTLP/HTTP.
+func startOtlpHttpClient(ctx context.Context, cfg *collection.Config, views ...sdkmetric.View) (metric.MeterProvider, func(*http.ServeMux), func(), error) {
+	endpoint := os.Getenv("OTLP_EXPORTER_OTLP_ENDPOINT")
+	if endpoint == "" {
+		endpoint = "0.0.0.0:4318"
 	}
-	err = yaml.Unmarshal(yamlFile, c)
+	exporter, err := otlpmetrichttp.New(ctx,
+		otlpmetrichttp.WithInsecure(),
+		otlpmetrichttp.WithEndpoint(endpoint),
+		otlpmetrichttp.WithTemporalitySelector(temporalitySelector(cfg)),
+	)
 	if err != nil {
-		//more logs here
-		return c.getDefaultConfig()
+		return nil, nil, nil, fmt.Errorf("starting otlphttp metric exporter: %w", err)
 	}
-	return c
-}
-
-// Default to default values incase config file is missing
-func (c *conf) getDefaultConfig() *conf {
-	c.Host = defaultHost
-	c.Port = defaultPort
-	c.TimeAliveIncrementer = int64(defaultTimeAliveIncrementer)
-	c.TotalheapSizeUpperBound = int64(defaultTotalHeapSizeUpperBound)
-	c.ThreadsActiveUpperBound = int64(defaultThreadsActiveUpperBound)
-	c.CpuUsageUpperBound = int64(defaultCpuUsageUpperBound)
-	return c
+	queue := collection.NewBoundedQueue(cfg.AdmissionMaxBytes, cfg.AdmissionMaxWaiters)
+	mp, registerHandlers, shutdown := newPeriodicMeterProvider(collection.NewAdmissionControlledExporter(exporter, queue), queue, views...)
+	return mp, registerHandlers, shutdown, nil
 }
 
-func counterObserver(ctx context.Context) {
-	counter, _ := meter.SyncInt64().Counter(
-		"Time Alive",
-		instrument.WithUnit("s"),
-		instrument.WithDescription("Total time that the application has been alive for"),
+// startOtlpGrpcClient pushes metrics to a collector over OTLP/gRPC.
+func startOtlpGrpcClient(ctx context.Context, cfg *collection.Config, views ...sdkmetric.View) (metric.MeterProvider, func(*http.ServeMux), func(), error) {
+	endpoint := os.Getenv("OTLP_EXPORTER_OTLP_ENDPOINT")
+	if endpoint == "" {
+		endpoint = "0.0.0.0:4317"
+	}
+	exporter, err := otlpmetricgrpc.New(ctx,
+		otlpmetricgrpc.WithInsecure(),
+		otlpmetricgrpc.WithEndpoint(endpoint),
+		otlpmetricgrpc.WithTemporalitySelector(temporalitySelector(cfg)),
 	)
-	counter.Add(ctx, 1)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("starting otlpgrpc metric exporter: %w", err)
+	}
+	queue := collection.NewBoundedQueue(cfg.AdmissionMaxBytes, cfg.AdmissionMaxWaiters)
+	mp, registerHandlers, shutdown := newPeriodicMeterProvider(collection.NewAdmissionControlledExporter(exporter, queue), queue, views...)
+	return mp, registerHandlers, shutdown, nil
 }
 
-func asyncGaugeObserver(ctx context.Context) {
-	gauge, _ := meter.AsyncInt64().Gauge(
-		"CPU Usage",
-		instrument.WithUnit("%"),
-		instrument.WithDescription("Cpu usage percent"),
+// newPeriodicMeterProvider wraps a push exporter in a PeriodicReader and registers the
+// resulting MeterProvider as the global one, shared by both OTLP transports. If queue is
+// non-nil, its in-flight bytes and waiter count are exposed as self-observability gauges.
+// The returned handler-registration function always exposes a /flush endpoint that
+// force-flushes the reader on demand. A single PeriodicReader only ever has one export in flight on its own
+// timer, so the admission queue's FIFO wait-list and ErrTooManyWaiters paths need requests
+// against /flush racing that timer to actually contend for capacity; driving /flush
+// concurrently (e.g. with a small load-testing tool) against a slow collector is how to
+// observe that contention.
+func newPeriodicMeterProvider(exporter sdkmetric.Exporter, queue *collection.BoundedQueue, views ...sdkmetric.View) (metric.MeterProvider, func(*http.ServeMux), func()) {
+	mp := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter, sdkmetric.WithInterval(3*time.Second))),
+		sdkmetric.WithView(views...),
 	)
+	otel.SetMeterProvider(mp)
+	if queue != nil {
+		if err := queue.RegisterSelfObservability(mp.Meter("OTLP_METRIC_SAMPLE_APP")); err != nil {
+			otel.Handle(err)
+		}
+	}
 
-	if err := meter.RegisterCallback(
-		[]instrument.Asynchronous{
-			gauge,
-		},
-		// SDK periodically calls this function to collect data.
-		func(ctx context.Context) {
-			min := 0
-			max := defaultCpuUsageUpperBound
-			cpuUsage := int64(rand.Intn(max-min) + min)
-			gauge.Observe(ctx, cpuUsage)
-			fmt.Println(cpuUsage)
-		},
-	); err != nil {
-		panic(err)
+	registerHandlers := func(mux *http.ServeMux) {
+		mux.HandleFunc("/flush", func(w http.ResponseWriter, r *http.Request) {
+			if err := mp.ForceFlush(r.Context()); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			fmt.Fprintln(w, "flushed")
+		})
 	}
-}
 
-func asyncUpDownCounterObserver(ctx context.Context) {
-	upDownCounter, _ := meter.AsyncInt64().UpDownCounter(
-		"Total Heap Size",
-		instrument.WithUnit("1"),
-		instrument.WithDescription("The current total heap size"),
-	)
+	return mp, registerHandlers, func() {
+		cxt, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
 
-	if err := meter.RegisterCallback(
-		[]instrument.Asynchronous{
-			upDownCounter,
-		},
-		// SDK periodically calls this function to collect data.
-		func(ctx context.Context) {
-			min := 0
-			max := defaultTotalHeapSizeUpperBound
-			totalHeapSize := int64(rand.Intn(max-min) + min)
-			upDownCounter.Observe(ctx, totalHeapSize)
-			fmt.Println(totalHeapSize)
-		},
-	); err != nil {
-		panic(err)
+		// pushes any last exports to the receiver
+		if err := mp.Shutdown(cxt); err != nil {
+			otel.Handle(err)
+		}
 	}
-
 }
 
-func upDownCounterObserver(ctx context.Context) {
-	upDownCounter, _ := meter.SyncInt64().UpDownCounter(
-		"Threads Active",
-		instrument.WithUnit("1"),
-		instrument.WithDescription("The total amount of threads active"),
+// startPrometheusClient serves metrics for pull-based scraping instead of pushing them to a
+// collector: it registers a Prometheus reader as the global MeterProvider and, via the
+// returned handler-registration function, exposes the registry on the sample app's shared
+// mux so a Prometheus server (or an OTel Collector prometheus receiver) can scrape it.
+func startPrometheusClient(cfg *collection.Config, views ...sdkmetric.View) (metric.MeterProvider, func(*http.ServeMux), func(), error) {
+	reader, err := prometheus.New()
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("starting prometheus metric reader: %w", err)
+	}
+	mp := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(reader),
+		sdkmetric.WithView(views...),
 	)
+	otel.SetMeterProvider(mp)
 
-	if threadsBool {
-		if threadsActive < int64(defaultThreadsActiveUpperBound) {
-			upDownCounter.Add(ctx, 1)
-			threadsActive++
-		} else {
-			threadsBool = false
-			threadsActive--
-		}
-
-	} else {
-		if threadsActive > 0 {
-			upDownCounter.Add(ctx, -1)
-			threadsActive--
-		} else {
-			threadsBool = true
-			threadsActive++
-		}
+	registerHandlers := func(mux *http.ServeMux) {
+		mux.Handle("/metrics", promhttp.Handler())
 	}
-	fmt.Println(threadsActive)
 
-}
-
-func updateLoop(ctx context.Context) {
-	go func() {
-		for {
-			upDownCounterObserver(ctx)
-			//gaugeObserver(ctx)
-			//counterObserver(ctx)
-			time.Sleep(time.Second * 1)
-			log.Print("Updating TimeAlive...")
+	return mp, registerHandlers, func() {
+		cxt, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		if err := mp.Shutdown(cxt); err != nil {
+			otel.Handle(err)
 		}
-	}()
+	}, nil
 }